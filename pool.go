@@ -0,0 +1,838 @@
+// Package pool implements a generic resource pool with bounded size and
+// background lifecycle management.
+package pool
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrClosedPool is returned by Get when the pool has already been closed.
+var ErrClosedPool = errors.New("closed pool")
+
+// ErrPoolShuttingDown is the cause a blocked Get or a resource still checked
+// out observes while Shutdown is draining the pool.
+var ErrPoolShuttingDown = errors.New("pool shutting down")
+
+// ErrPoolClosed is the cause left in place once Shutdown finishes draining
+// the pool, unless the caller supplied its own cause via CloseWithCause.
+var ErrPoolClosed = errors.New("pool closed")
+
+// CreateFunc constructs a new resource for the pool.
+type CreateFunc func() (interface{}, error)
+
+// CloseFunc releases a resource that the pool no longer needs.
+type CloseFunc func(interface{}) error
+
+// ResetFunc prepares a resource to be reused after it is returned to the
+// pool. If it returns an error, the resource is closed instead of becoming
+// idle.
+type ResetFunc func(interface{}) error
+
+// ValidateFunc reports whether an idle resource is still usable. It is
+// called from Get before an idle resource is handed to a caller; if it
+// returns false, the resource is closed and the next idle resource (or a
+// freshly created one) is used instead.
+type ValidateFunc func(interface{}) bool
+
+// resourceWrapper tracks the bookkeeping the pool needs for a resource in
+// addition to the value the caller sees.
+type resourceWrapper struct {
+	value      interface{}
+	createdAt  time.Time
+	lastUsedAt time.Time
+}
+
+// Pool manages the lifecycle of a fixed-size set of resources, constructing
+// them on demand with createFunc and reusing them across callers until they
+// are closed with closeFunc.
+type Pool struct {
+	mutex sync.Mutex
+	cond  *sync.Cond
+
+	createFunc   CreateFunc
+	closeFunc    CloseFunc
+	resetFunc    ResetFunc
+	validateFunc ValidateFunc
+
+	maxSize int
+	size    int
+
+	idle     []*resourceWrapper
+	acquired map[interface{}]*resourceWrapper
+
+	closed     bool
+	closeCause error
+
+	maxIdleTime time.Duration
+	maxLifetime time.Duration
+	reaperStop  chan struct{}
+	reaperDone  chan struct{}
+
+	minSize                 int
+	pendingCreates          int
+	retryPolicy             RetryPolicy
+	failedBackgroundCreates int64
+
+	// closingCount counts resources that have left p.acquired or stopped
+	// being a pending background create, but whose resetFunc/closeFunc call
+	// is still in flight. Shutdown's drain wait must not report success
+	// until this reaches zero too, or it can return before every resource
+	// is actually closed.
+	closingCount int
+
+	acquireCount         int64
+	acquireDuration      time.Duration
+	emptyAcquireCount    int64
+	canceledAcquireCount int64
+	createdResources     int64
+	closedResources      int64
+}
+
+// Stat is a snapshot of a Pool's counters, suitable for exposing to an
+// observability system like Prometheus.
+type Stat struct {
+	TotalResources        int
+	IdleResources         int
+	AcquiredResources     int
+	ConstructingResources int
+	MaxResources          int
+
+	AcquireCount         int64
+	AcquireDuration      time.Duration
+	EmptyAcquireCount    int64
+	CanceledAcquireCount int64
+
+	CreatedResources        int64
+	ClosedResources         int64
+	FailedBackgroundCreates int64
+}
+
+// Stat returns a snapshot of the pool's counters.
+func (p *Pool) Stat() Stat {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return Stat{
+		TotalResources:        p.size,
+		IdleResources:         len(p.idle),
+		AcquiredResources:     len(p.acquired),
+		ConstructingResources: p.size - len(p.idle) - len(p.acquired),
+		MaxResources:          p.maxSize,
+
+		AcquireCount:         p.acquireCount,
+		AcquireDuration:      p.acquireDuration,
+		EmptyAcquireCount:    p.emptyAcquireCount,
+		CanceledAcquireCount: p.canceledAcquireCount,
+
+		CreatedResources:        p.createdResources,
+		ClosedResources:         p.closedResources,
+		FailedBackgroundCreates: p.failedBackgroundCreates,
+	}
+}
+
+// recordAcquire updates the acquire counters for a Get call that returned a
+// resource successfully. wasEmpty reports whether Get had to wait for the
+// resource (no idle resource was immediately available).
+func (p *Pool) recordAcquire(start time.Time, wasEmpty bool) {
+	d := time.Since(start)
+	p.mutex.Lock()
+	p.acquireCount++
+	p.acquireDuration += d
+	if wasEmpty {
+		p.emptyAcquireCount++
+	}
+	p.mutex.Unlock()
+}
+
+// recordCanceledAcquire updates the counters for a Get call that returned
+// because its context was done.
+func (p *Pool) recordCanceledAcquire() {
+	p.mutex.Lock()
+	p.canceledAcquireCount++
+	p.mutex.Unlock()
+}
+
+// recordCreated updates the counters after a successful createFunc call.
+func (p *Pool) recordCreated() {
+	p.mutex.Lock()
+	p.createdResources++
+	p.mutex.Unlock()
+}
+
+// recordClosed updates the counters after a closeFunc call.
+func (p *Pool) recordClosed() {
+	p.mutex.Lock()
+	p.closedResources++
+	p.mutex.Unlock()
+}
+
+// RetryPolicy controls the backoff between a background refill's createFunc
+// attempts after a failure.
+type RetryPolicy struct {
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64
+}
+
+// defaultRetryPolicy is used until SetCreateRetryPolicy is called.
+var defaultRetryPolicy = RetryPolicy{
+	InitialInterval: 100 * time.Millisecond,
+	MaxInterval:     30 * time.Second,
+	Multiplier:      2,
+}
+
+// New creates a new Pool. createFunc is called to construct new resources
+// and closeFunc is called to release resources the pool no longer needs.
+// The pool has no maximum size until SetMaxSize is called.
+func New(createFunc CreateFunc, closeFunc CloseFunc) *Pool {
+	p := &Pool{
+		createFunc:  createFunc,
+		closeFunc:   closeFunc,
+		maxSize:     math.MaxInt32,
+		acquired:    make(map[interface{}]*resourceWrapper),
+		retryPolicy: defaultRetryPolicy,
+	}
+	p.cond = sync.NewCond(&p.mutex)
+	return p
+}
+
+// NewWithHooks creates a new Pool like New, additionally installing
+// resetFunc to run on every Return and validateFunc to run on every Get.
+// Either may be nil to skip that hook.
+func NewWithHooks(createFunc CreateFunc, closeFunc CloseFunc, resetFunc ResetFunc, validateFunc ValidateFunc) *Pool {
+	p := New(createFunc, closeFunc)
+	p.resetFunc = resetFunc
+	p.validateFunc = validateFunc
+	return p
+}
+
+// SetMaxSize sets the maximum number of resources the pool will manage at
+// once, counting both idle and acquired resources.
+func (p *Pool) SetMaxSize(n int) {
+	p.mutex.Lock()
+	p.maxSize = n
+	p.mutex.Unlock()
+	p.cond.Broadcast()
+}
+
+// SetMinSize sets how many idle resources the pool tries to keep warm. On
+// the first call it immediately spawns enough background creations to reach
+// n (bounded by MaxSize); afterwards, any time the idle count drops below n
+// it enqueues more background creations to bring it back up. Background
+// creations count toward Size() as soon as they're started and never block
+// a caller of Get.
+func (p *Pool) SetMinSize(n int) {
+	p.mutex.Lock()
+	p.minSize = n
+	p.mutex.Unlock()
+	p.refill()
+}
+
+// SetCreateRetryPolicy sets the backoff used when a background refill's
+// createFunc call fails. It has no effect on errors returned directly from
+// Get.
+func (p *Pool) SetCreateRetryPolicy(policy RetryPolicy) {
+	p.mutex.Lock()
+	p.retryPolicy = policy
+	p.mutex.Unlock()
+}
+
+// refill tops the idle set up toward minSize in the background, without
+// blocking the caller. It never creates more than maxSize total resources
+// and accounts for creations already in flight so it doesn't over-spawn.
+func (p *Pool) refill() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for len(p.idle)+p.pendingCreates < p.minSize && p.size < p.maxSize {
+		p.size++
+		p.pendingCreates++
+		go p.backgroundCreate()
+	}
+}
+
+// backgroundCreate constructs one resource for refill, retrying createFunc
+// with exponential backoff on error until it succeeds or the pool is
+// closed.
+func (p *Pool) backgroundCreate() {
+	p.mutex.Lock()
+	delay := p.retryPolicy.InitialInterval
+	p.mutex.Unlock()
+
+	for {
+		p.mutex.Lock()
+		if p.closed {
+			p.size--
+			p.pendingCreates--
+			p.mutex.Unlock()
+			p.cond.Broadcast()
+			return
+		}
+		p.mutex.Unlock()
+
+		value, err := p.createFunc()
+		if err != nil {
+			p.mutex.Lock()
+			p.failedBackgroundCreates++
+			policy := p.retryPolicy
+			p.mutex.Unlock()
+
+			time.Sleep(delay)
+			if policy.Multiplier > 1 {
+				delay = time.Duration(float64(delay) * policy.Multiplier)
+			}
+			if policy.MaxInterval > 0 && delay > policy.MaxInterval {
+				delay = policy.MaxInterval
+			}
+			continue
+		}
+
+		p.recordCreated()
+		w := &resourceWrapper{value: value, createdAt: time.Now(), lastUsedAt: time.Now()}
+		p.mutex.Lock()
+		p.pendingCreates--
+		if p.closed {
+			p.size--
+			p.closingCount++
+			p.mutex.Unlock()
+			p.closeFunc(value)
+			p.recordClosed()
+			p.mutex.Lock()
+			p.closingCount--
+			p.mutex.Unlock()
+			p.cond.Broadcast()
+			return
+		}
+		p.idle = append(p.idle, w)
+		p.mutex.Unlock()
+		p.cond.Broadcast()
+		return
+	}
+}
+
+// Size returns the number of resources currently managed by the pool,
+// whether idle or acquired.
+func (p *Pool) Size() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.size
+}
+
+// TryGet returns an idle resource immediately, or (nil, false) if none is
+// available or the pool is closed. Unlike Get, it never waits for a resource
+// to be returned and never constructs a new one.
+func (p *Pool) TryGet() (interface{}, bool) {
+	start := time.Now()
+
+	p.mutex.Lock()
+	if p.closed {
+		p.mutex.Unlock()
+		return nil, false
+	}
+
+	w := p.popValidIdle()
+	if w == nil {
+		p.mutex.Unlock()
+		return nil, false
+	}
+	// popValidIdle releases p.mutex to call validateFunc, so the pool may
+	// have been closed while w was being validated.
+	if p.closed {
+		p.size--
+		p.mutex.Unlock()
+		p.closeFunc(w.value)
+		p.recordClosed()
+		return nil, false
+	}
+	w.lastUsedAt = time.Now()
+	p.acquired[w.value] = w
+	p.mutex.Unlock()
+
+	p.refill()
+	p.recordAcquire(start, false)
+	return w.value, true
+}
+
+// AcquireAllIdle atomically removes every currently idle resource from the
+// pool and returns their values, marking each one acquired so a later
+// Return works normally. It is safe to call on a closed pool, which always
+// returns an empty slice.
+func (p *Pool) AcquireAllIdle() []interface{} {
+	p.mutex.Lock()
+	if p.closed || len(p.idle) == 0 {
+		p.mutex.Unlock()
+		return nil
+	}
+
+	idle := p.idle
+	p.idle = nil
+
+	now := time.Now()
+	values := make([]interface{}, len(idle))
+	for i, w := range idle {
+		w.lastUsedAt = now
+		p.acquired[w.value] = w
+		values[i] = w.value
+	}
+	p.acquireCount += int64(len(values))
+	p.mutex.Unlock()
+
+	p.refill()
+	return values
+}
+
+// Get returns an idle resource or constructs a new one via createFunc,
+// blocking until one becomes available, ctx is canceled, or the pool is
+// closed. The caller must pass the returned value to Return once it is done
+// with it.
+func (p *Pool) Get(ctx context.Context) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		p.recordCanceledAcquire()
+		return nil, err
+	}
+
+	start := time.Now()
+	wasEmpty := false
+
+	p.mutex.Lock()
+
+	var watching bool
+	var stopWatch chan struct{}
+
+	for {
+		if p.closed {
+			if watching {
+				close(stopWatch)
+			}
+			cause := p.closeCause
+			p.mutex.Unlock()
+			return nil, cause
+		}
+
+		if w := p.popValidIdle(); w != nil {
+			// popValidIdle releases p.mutex to call validateFunc, so the pool
+			// may have been closed while w was being validated; don't hand a
+			// resource from a closed pool to the caller.
+			if p.closed {
+				p.size--
+				p.mutex.Unlock()
+				p.closeFunc(w.value)
+				p.recordClosed()
+				p.mutex.Lock()
+				continue
+			}
+
+			w.lastUsedAt = time.Now()
+			p.acquired[w.value] = w
+			if watching {
+				close(stopWatch)
+			}
+			p.mutex.Unlock()
+			p.refill()
+			p.recordAcquire(start, wasEmpty)
+			return w.value, nil
+		}
+
+		if p.size < p.maxSize {
+			wasEmpty = true
+			p.size++
+			if watching {
+				close(stopWatch)
+			}
+			p.mutex.Unlock()
+			value, err := p.createResource(ctx)
+			if err != nil {
+				return nil, err
+			}
+			p.recordAcquire(start, wasEmpty)
+			return value, nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			if watching {
+				close(stopWatch)
+			}
+			p.mutex.Unlock()
+			p.recordCanceledAcquire()
+			return nil, err
+		}
+
+		wasEmpty = true
+		if !watching {
+			watching = true
+			stopWatch = make(chan struct{})
+			go p.watchContext(ctx, stopWatch)
+		}
+
+		p.cond.Wait()
+	}
+}
+
+// popValidIdle pops resources off the idle list until it finds one that
+// passes validateFunc, closing any that don't along the way, or the list is
+// exhausted. It must be called with p.mutex held and returns with it held.
+func (p *Pool) popValidIdle() *resourceWrapper {
+	for {
+		n := len(p.idle)
+		if n == 0 {
+			return nil
+		}
+		w := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+
+		if p.validateFunc == nil {
+			return w
+		}
+
+		p.mutex.Unlock()
+		ok := p.validateFunc(w.value)
+		p.mutex.Lock()
+		if ok {
+			return w
+		}
+
+		p.size--
+		p.mutex.Unlock()
+		p.closeFunc(w.value)
+		p.recordClosed()
+		p.mutex.Lock()
+	}
+}
+
+// watchContext wakes any goroutines blocked in Get's cond.Wait when ctx is
+// canceled, since sync.Cond has no way to select on a context directly.
+func (p *Pool) watchContext(ctx context.Context, stop chan struct{}) {
+	select {
+	case <-ctx.Done():
+		p.mutex.Lock()
+		p.cond.Broadcast()
+		p.mutex.Unlock()
+	case <-stop:
+	}
+}
+
+// createResource constructs a new resource, canceling the wait (but not the
+// construction itself) if ctx is done first. A resource that finishes
+// constructing after its caller gave up is handed to the next waiter instead
+// of being discarded.
+func (p *Pool) createResource(ctx context.Context) (interface{}, error) {
+	resCh := make(chan interface{}, 1)
+	errCh := make(chan error, 1)
+	go func() {
+		value, err := p.createFunc()
+		if err != nil {
+			errCh <- err
+			return
+		}
+		resCh <- value
+	}()
+
+	select {
+	case value := <-resCh:
+		p.recordCreated()
+		w := &resourceWrapper{value: value, createdAt: time.Now(), lastUsedAt: time.Now()}
+		p.mutex.Lock()
+		p.acquired[value] = w
+		p.mutex.Unlock()
+		return value, nil
+	case err := <-errCh:
+		p.mutex.Lock()
+		p.size--
+		p.mutex.Unlock()
+		p.cond.Broadcast()
+		return nil, err
+	case <-ctx.Done():
+		p.recordCanceledAcquire()
+		go p.finishAbandonedCreate(resCh, errCh)
+		return nil, ctx.Err()
+	}
+}
+
+// finishAbandonedCreate waits for a createFunc call whose caller already gave
+// up on ctx, then either hands the result to the idle set or accounts for the
+// failure.
+func (p *Pool) finishAbandonedCreate(resCh <-chan interface{}, errCh <-chan error) {
+	select {
+	case value := <-resCh:
+		p.recordCreated()
+		w := &resourceWrapper{value: value, createdAt: time.Now(), lastUsedAt: time.Now()}
+		p.mutex.Lock()
+		p.idle = append(p.idle, w)
+		p.mutex.Unlock()
+		p.cond.Broadcast()
+	case <-errCh:
+		p.mutex.Lock()
+		p.size--
+		p.mutex.Unlock()
+		p.cond.Broadcast()
+	}
+}
+
+// Return gives a resource previously obtained from Get back to the pool. It
+// panics if value was not acquired from this pool. If resetFunc is set, it
+// runs before the resource rejoins the idle set; a reset error, like the
+// pool already being closed, causes the resource to be closed instead.
+func (p *Pool) Return(value interface{}) {
+	p.mutex.Lock()
+	w, ok := p.acquired[value]
+	if !ok {
+		p.mutex.Unlock()
+		panic("pool: returned resource that is not part of the pool")
+	}
+	delete(p.acquired, value)
+	p.closingCount++
+	p.mutex.Unlock()
+
+	discard := false
+	if p.resetFunc != nil {
+		if err := p.resetFunc(value); err != nil {
+			discard = true
+		}
+	}
+
+	p.mutex.Lock()
+	if discard || p.closed {
+		p.size--
+		p.mutex.Unlock()
+		p.closeFunc(value)
+		p.recordClosed()
+		p.mutex.Lock()
+		p.closingCount--
+		p.mutex.Unlock()
+		p.cond.Broadcast()
+		return
+	}
+
+	w.lastUsedAt = time.Now()
+	p.idle = append(p.idle, w)
+	p.closingCount--
+	p.mutex.Unlock()
+	p.cond.Broadcast()
+}
+
+// Close marks the pool as closed, closes all currently idle resources, and
+// stops the reaper goroutine if one is running. Resources that are still
+// acquired are closed as they are returned. Get returns ErrClosedPool for
+// any caller waiting on or arriving after Close.
+func (p *Pool) Close() {
+	p.CloseWithCause(ErrClosedPool)
+}
+
+// CloseWithCause does what Close does, but callers blocked in Get, later Get
+// calls, and any later Shutdown call see cause instead of ErrClosedPool. A
+// nil cause is treated as ErrClosedPool.
+func (p *Pool) CloseWithCause(cause error) {
+	if cause == nil {
+		cause = ErrClosedPool
+	}
+
+	p.mutex.Lock()
+	if p.closed {
+		p.mutex.Unlock()
+		return
+	}
+	p.closed = true
+	p.closeCause = cause
+	idle := p.idle
+	p.idle = nil
+	p.size -= len(idle)
+	p.mutex.Unlock()
+
+	p.stopReaper()
+
+	for _, w := range idle {
+		p.closeFunc(w.value)
+		p.recordClosed()
+	}
+
+	p.cond.Broadcast()
+}
+
+// Shutdown stops the pool from accepting new Get calls and waits for every
+// currently acquired resource to be returned and every in-flight background
+// create (from SetMinSize's refill) to finish and be closed, closing each
+// one as it comes back. It blocks until the pool is fully drained or ctx is
+// done, whichever comes first.
+//
+// If ctx fires before the pool drains, any idle resources are closed
+// immediately, outstanding resources are closed as soon as they are
+// eventually returned (rather than rejoining the idle set), and Shutdown
+// returns context.Cause(ctx). Callers blocked in Get when Shutdown starts
+// are woken with ErrPoolShuttingDown; Get calls made after ctx fires see
+// context.Cause(ctx).
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mutex.Lock()
+	if p.closed {
+		cause := p.closeCause
+		p.mutex.Unlock()
+		return cause
+	}
+	p.closed = true
+	p.closeCause = ErrPoolShuttingDown
+	idle := p.idle
+	p.idle = nil
+	p.size -= len(idle)
+	p.mutex.Unlock()
+
+	p.stopReaper()
+
+	for _, w := range idle {
+		p.closeFunc(w.value)
+		p.recordClosed()
+	}
+	p.cond.Broadcast()
+
+	drained := make(chan struct{})
+	go func() {
+		p.mutex.Lock()
+		for len(p.acquired) > 0 || p.pendingCreates > 0 || p.closingCount > 0 {
+			p.cond.Wait()
+		}
+		p.mutex.Unlock()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		p.mutex.Lock()
+		p.closeCause = ErrPoolClosed
+		p.mutex.Unlock()
+		return nil
+	case <-ctx.Done():
+		cause := context.Cause(ctx)
+		p.mutex.Lock()
+		p.closeCause = cause
+		p.mutex.Unlock()
+		p.cond.Broadcast()
+		return cause
+	}
+}
+
+// SetMaxIdleTime sets how long a resource may sit idle before the reaper
+// closes it. A value of zero (the default) disables idle expiration. The
+// reaper goroutine is started lazily the first time SetMaxIdleTime or
+// SetMaxLifetime is called.
+func (p *Pool) SetMaxIdleTime(d time.Duration) {
+	p.mutex.Lock()
+	p.maxIdleTime = d
+	p.mutex.Unlock()
+	p.ensureReaper()
+}
+
+// SetMaxLifetime sets how long a resource may exist, regardless of idle
+// time, before the reaper closes it. A value of zero (the default) disables
+// lifetime expiration. The reaper goroutine is started lazily the first time
+// SetMaxIdleTime or SetMaxLifetime is called.
+func (p *Pool) SetMaxLifetime(d time.Duration) {
+	p.mutex.Lock()
+	p.maxLifetime = d
+	p.mutex.Unlock()
+	p.ensureReaper()
+}
+
+// ensureReaper starts the reaper goroutine if it isn't already running.
+func (p *Pool) ensureReaper() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.closed || p.reaperStop != nil {
+		return
+	}
+	p.reaperStop = make(chan struct{})
+	p.reaperDone = make(chan struct{})
+	go p.reapLoop(p.reaperStop, p.reaperDone)
+}
+
+// stopReaper stops the reaper goroutine, if running, and waits for it to
+// exit.
+func (p *Pool) stopReaper() {
+	p.mutex.Lock()
+	stop := p.reaperStop
+	done := p.reaperDone
+	p.mutex.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+// reapLoop wakes on a ticker at min(maxIdleTime, maxLifetime)/2 and closes
+// any idle resource that has exceeded its idle time or lifetime bound. It
+// never blocks Get or Return, since it only ever touches the idle list.
+func (p *Pool) reapLoop(stop, done chan struct{}) {
+	defer close(done)
+	for {
+		interval := p.reapInterval()
+		if interval <= 0 {
+			// Let a later SetMaxIdleTime or SetMaxLifetime start a new reaper.
+			p.mutex.Lock()
+			if p.reaperStop == stop {
+				p.reaperStop = nil
+				p.reaperDone = nil
+			}
+			p.mutex.Unlock()
+			return
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+			p.reapOnce()
+		case <-stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// reapInterval returns how long the reaper should sleep between sweeps, or
+// zero if neither bound is configured.
+func (p *Pool) reapInterval() time.Duration {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	min := p.maxIdleTime
+	if p.maxLifetime > 0 && (min <= 0 || p.maxLifetime < min) {
+		min = p.maxLifetime
+	}
+	if min <= 0 {
+		return 0
+	}
+	return min / 2
+}
+
+// reapOnce closes every idle resource that has exceeded maxIdleTime or
+// maxLifetime.
+func (p *Pool) reapOnce() {
+	now := time.Now()
+
+	p.mutex.Lock()
+	kept := p.idle[:0]
+	var expired []*resourceWrapper
+	for _, w := range p.idle {
+		idleExpired := p.maxIdleTime > 0 && now.Sub(w.lastUsedAt) >= p.maxIdleTime
+		lifetimeExpired := p.maxLifetime > 0 && now.Sub(w.createdAt) >= p.maxLifetime
+		if idleExpired || lifetimeExpired {
+			expired = append(expired, w)
+		} else {
+			kept = append(kept, w)
+		}
+	}
+	p.idle = kept
+	p.size -= len(expired)
+	p.mutex.Unlock()
+
+	if len(expired) == 0 {
+		return
+	}
+
+	for _, w := range expired {
+		p.closeFunc(w.value)
+		p.recordClosed()
+	}
+	p.cond.Broadcast()
+	p.refill()
+}
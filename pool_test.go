@@ -226,6 +226,598 @@ func TestPoolGetReturnsErrorWhenPoolIsClosed(t *testing.T) {
 	assert.Nil(t, res)
 }
 
+func TestPoolSetMaxIdleTimeClosesIdleResourceAfterTimeout(t *testing.T) {
+	var createCalls Counter
+	createFunc := func() (interface{}, error) {
+		return createCalls.Next(), nil
+	}
+
+	var closeCalls Counter
+	closeFunc := func(interface{}) error {
+		closeCalls.Next()
+		return nil
+	}
+
+	p := pool.New(createFunc, closeFunc)
+	p.SetMaxIdleTime(20 * time.Millisecond)
+
+	res, err := p.Get(context.Background())
+	require.NoError(t, err)
+	p.Return(res)
+
+	require.Eventually(t, func() bool {
+		return closeCalls.Value() == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 0, p.Size())
+}
+
+func TestPoolSetMaxLifetimeClosesResourceAfterTimeout(t *testing.T) {
+	var createCalls Counter
+	createFunc := func() (interface{}, error) {
+		return createCalls.Next(), nil
+	}
+
+	var closeCalls Counter
+	closeFunc := func(interface{}) error {
+		closeCalls.Next()
+		return nil
+	}
+
+	p := pool.New(createFunc, closeFunc)
+	p.SetMaxLifetime(20 * time.Millisecond)
+
+	res, err := p.Get(context.Background())
+	require.NoError(t, err)
+	p.Return(res)
+
+	require.Eventually(t, func() bool {
+		return closeCalls.Value() == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 0, p.Size())
+}
+
+func TestPoolCloseJoinsReaperGoroutinePromptly(t *testing.T) {
+	var createCalls Counter
+	createFunc := func() (interface{}, error) {
+		return createCalls.Next(), nil
+	}
+	p := pool.New(createFunc, stubCloseRes)
+
+	// Long enough that the test would time out waiting on the reaper's
+	// ticker if Close didn't interrupt it.
+	p.SetMaxIdleTime(time.Hour)
+
+	res, err := p.Get(context.Background())
+	require.NoError(t, err)
+	p.Return(res)
+
+	closed := make(chan struct{})
+	go func() {
+		p.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close did not return promptly; reaper goroutine was not stopped")
+	}
+}
+
+func TestPoolReturnRunsResetFuncAndClosesOnError(t *testing.T) {
+	var createCalls Counter
+	createFunc := func() (interface{}, error) {
+		return createCalls.Next(), nil
+	}
+
+	var closeCalls Counter
+	closeFunc := func(interface{}) error {
+		closeCalls.Next()
+		return nil
+	}
+
+	errReset := errors.New("reset failed")
+	resetFunc := func(res interface{}) error {
+		if res == 1 {
+			return errReset
+		}
+		return nil
+	}
+
+	p := pool.NewWithHooks(createFunc, closeFunc, resetFunc, nil)
+
+	res, err := p.Get(context.Background())
+	require.NoError(t, err)
+	p.Return(res)
+
+	assert.Equal(t, 1, closeCalls.Value())
+	assert.Equal(t, 0, p.Size())
+
+	res, err = p.Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, res)
+	p.Return(res)
+
+	assert.Equal(t, 1, closeCalls.Value())
+}
+
+func TestPoolGetDiscardsResourcesThatFailValidation(t *testing.T) {
+	var createCalls Counter
+	createFunc := func() (interface{}, error) {
+		return createCalls.Next(), nil
+	}
+
+	var closeCalls Counter
+	closeFunc := func(interface{}) error {
+		closeCalls.Next()
+		return nil
+	}
+
+	validateFunc := func(res interface{}) bool {
+		return res != 1
+	}
+
+	p := pool.NewWithHooks(createFunc, closeFunc, nil, validateFunc)
+
+	res, err := p.Get(context.Background())
+	require.NoError(t, err)
+	p.Return(res)
+
+	res, err = p.Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, res)
+
+	assert.Equal(t, 1, closeCalls.Value())
+	p.Return(res)
+}
+
+func TestPoolGetRejectsResourceValidatedDuringConcurrentClose(t *testing.T) {
+	var createCalls Counter
+	createFunc := func() (interface{}, error) {
+		return createCalls.Next(), nil
+	}
+
+	var closeCalls Counter
+	closeFunc := func(interface{}) error {
+		closeCalls.Next()
+		return nil
+	}
+
+	validateStarted := make(chan struct{})
+	releaseValidate := make(chan struct{})
+	validateFunc := func(interface{}) bool {
+		close(validateStarted)
+		<-releaseValidate
+		return true
+	}
+
+	p := pool.NewWithHooks(createFunc, closeFunc, nil, validateFunc)
+
+	res, err := p.Get(context.Background())
+	require.NoError(t, err)
+	p.Return(res)
+
+	getErr := make(chan error, 1)
+	go func() {
+		_, err := p.Get(context.Background())
+		getErr <- err
+	}()
+
+	<-validateStarted
+	p.Close()
+	close(releaseValidate)
+
+	assert.Equal(t, pool.ErrClosedPool, <-getErr)
+	assert.Equal(t, 1, closeCalls.Value())
+}
+
+func TestPoolShutdownWaitsForOutstandingResourcesThenCloses(t *testing.T) {
+	var createCalls Counter
+	createFunc := func() (interface{}, error) {
+		return createCalls.Next(), nil
+	}
+
+	var closeCalls Counter
+	closeFunc := func(interface{}) error {
+		closeCalls.Next()
+		return nil
+	}
+
+	p := pool.New(createFunc, closeFunc)
+
+	res, err := p.Get(context.Background())
+	require.NoError(t, err)
+
+	shutdownErr := make(chan error, 1)
+	shutdownStarted := make(chan struct{})
+	go func() {
+		close(shutdownStarted)
+		shutdownErr <- p.Shutdown(context.Background())
+	}()
+	<-shutdownStarted
+
+	p.Return(res)
+
+	assert.NoError(t, <-shutdownErr)
+	assert.Equal(t, 1, closeCalls.Value())
+
+	_, err = p.Get(context.Background())
+	assert.Equal(t, pool.ErrPoolClosed, err)
+}
+
+func TestPoolShutdownReturnsCauseWhenContextExpiresFirst(t *testing.T) {
+	var createCalls Counter
+	createFunc := func() (interface{}, error) {
+		return createCalls.Next(), nil
+	}
+
+	var closeCalls Counter
+	closeFunc := func(interface{}) error {
+		closeCalls.Next()
+		return nil
+	}
+
+	p := pool.New(createFunc, closeFunc)
+
+	res, err := p.Get(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = p.Shutdown(ctx)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 0, closeCalls.Value())
+
+	p.Return(res)
+	assert.Equal(t, 1, closeCalls.Value())
+}
+
+func TestPoolShutdownWaitsForInFlightBackgroundCreate(t *testing.T) {
+	createStarted := make(chan struct{})
+	releaseCreate := make(chan struct{})
+	createFunc := func() (interface{}, error) {
+		close(createStarted)
+		<-releaseCreate
+		return 1, nil
+	}
+
+	var closeCalls Counter
+	closeFunc := func(interface{}) error {
+		closeCalls.Next()
+		return nil
+	}
+
+	p := pool.New(createFunc, closeFunc)
+	p.SetMinSize(1)
+	<-createStarted
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- p.Shutdown(context.Background())
+	}()
+
+	close(releaseCreate)
+
+	assert.NoError(t, <-shutdownErr)
+	assert.Equal(t, 1, closeCalls.Value())
+	assert.Equal(t, 0, p.Size())
+}
+
+func TestPoolShutdownWaitsForResetFuncStillRunningOnReturn(t *testing.T) {
+	var createCalls Counter
+	createFunc := func() (interface{}, error) {
+		return createCalls.Next(), nil
+	}
+
+	releaseReset := make(chan struct{})
+	gatedValue := 0
+	resetFunc := func(value interface{}) error {
+		if value == gatedValue {
+			<-releaseReset
+		}
+		return nil
+	}
+
+	var closeCalls Counter
+	closedValues := make(chan interface{}, 2)
+	closeFunc := func(value interface{}) error {
+		closeCalls.Next()
+		closedValues <- value
+		return nil
+	}
+
+	p := pool.NewWithHooks(createFunc, closeFunc, resetFunc, nil)
+
+	gated, err := p.Get(context.Background())
+	require.NoError(t, err)
+	gatedValue = gated.(int)
+
+	other, err := p.Get(context.Background())
+	require.NoError(t, err)
+
+	go p.Return(gated)
+
+	// Give Return's resetFunc call a chance to start and remove gated from
+	// p.acquired before the unrelated Return below finishes and broadcasts.
+	time.Sleep(20 * time.Millisecond)
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- p.Shutdown(context.Background())
+	}()
+
+	p.Return(other)
+
+	select {
+	case <-shutdownErr:
+		t.Fatal("Shutdown returned before the gated resource's resetFunc finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(releaseReset)
+
+	assert.NoError(t, <-shutdownErr)
+	assert.Equal(t, 2, closeCalls.Value())
+	assert.Equal(t, 0, p.Size())
+}
+
+func TestPoolCloseWithCauseIsObservedByLaterGet(t *testing.T) {
+	var createCalls Counter
+	createFunc := func() (interface{}, error) {
+		return createCalls.Next(), nil
+	}
+	p := pool.New(createFunc, stubCloseRes)
+
+	errShutdownForTest := errors.New("shutting down for test")
+	p.CloseWithCause(errShutdownForTest)
+
+	_, err := p.Get(context.Background())
+	assert.Equal(t, errShutdownForTest, err)
+}
+
+func TestPoolSetMinSizePrewarmsIdleResources(t *testing.T) {
+	var createCalls Counter
+	createFunc := func() (interface{}, error) {
+		return createCalls.Next(), nil
+	}
+	p := pool.New(createFunc, stubCloseRes)
+	// Cap the pool at the prewarmed size so the refill that follows Get
+	// cannot start a fourth creation.
+	p.SetMaxSize(3)
+
+	p.SetMinSize(3)
+
+	require.Eventually(t, func() bool {
+		return createCalls.Value() == 3
+	}, time.Second, time.Millisecond)
+	// Give the last background creation time to land in the idle set after
+	// incrementing the counter above.
+	time.Sleep(20 * time.Millisecond)
+
+	res, err := p.Get(context.Background())
+	require.NoError(t, err)
+	assert.NotNil(t, res)
+	assert.Equal(t, 3, createCalls.Value())
+}
+
+func TestPoolSetMinSizeRefillsAfterGetDrainsIdle(t *testing.T) {
+	var createCalls Counter
+	createFunc := func() (interface{}, error) {
+		return createCalls.Next(), nil
+	}
+	p := pool.New(createFunc, stubCloseRes)
+	p.SetMinSize(1)
+
+	require.Eventually(t, func() bool {
+		return p.Size() == 1
+	}, time.Second, time.Millisecond)
+
+	res, err := p.Get(context.Background())
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return p.Size() == 2
+	}, time.Second, time.Millisecond)
+
+	p.Return(res)
+}
+
+func TestPoolSetMinSizeRetriesFailedBackgroundCreates(t *testing.T) {
+	var createCalls Counter
+	errCreateFailed := errors.New("create failed")
+	createFunc := func() (interface{}, error) {
+		n := createCalls.Next()
+		if n < 3 {
+			return nil, errCreateFailed
+		}
+		return n, nil
+	}
+	p := pool.New(createFunc, stubCloseRes)
+	p.SetCreateRetryPolicy(pool.RetryPolicy{InitialInterval: time.Millisecond, MaxInterval: 10 * time.Millisecond, Multiplier: 2})
+	p.SetMinSize(1)
+
+	require.Eventually(t, func() bool {
+		return createCalls.Value() == 3
+	}, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	res, err := p.Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, res)
+	p.Return(res)
+}
+
+func TestPoolSetMinSizeClosesResourceThatFinishesCreatingAfterClose(t *testing.T) {
+	createStarted := make(chan struct{})
+	releaseCreate := make(chan struct{})
+	createFunc := func() (interface{}, error) {
+		close(createStarted)
+		<-releaseCreate
+		return 1, nil
+	}
+
+	var closeCalls Counter
+	closeFunc := func(interface{}) error {
+		closeCalls.Next()
+		return nil
+	}
+
+	p := pool.New(createFunc, closeFunc)
+	p.SetMinSize(1)
+
+	<-createStarted
+	p.Close()
+	close(releaseCreate)
+
+	require.Eventually(t, func() bool {
+		return closeCalls.Value() == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 0, p.Size())
+}
+
+func TestPoolStatTracksAcquiresCreatesAndCloses(t *testing.T) {
+	var createCalls Counter
+	createFunc := func() (interface{}, error) {
+		return createCalls.Next(), nil
+	}
+
+	var closeCalls Counter
+	closeFunc := func(interface{}) error {
+		closeCalls.Next()
+		return nil
+	}
+
+	p := pool.New(createFunc, closeFunc)
+	p.SetMaxSize(2)
+
+	res1, err := p.Get(context.Background())
+	require.NoError(t, err)
+	res2, err := p.Get(context.Background())
+	require.NoError(t, err)
+
+	stat := p.Stat()
+	assert.Equal(t, 2, stat.TotalResources)
+	assert.Equal(t, 2, stat.AcquiredResources)
+	assert.Equal(t, 0, stat.IdleResources)
+	assert.Equal(t, 2, stat.MaxResources)
+	assert.EqualValues(t, 2, stat.AcquireCount)
+	assert.EqualValues(t, 2, stat.CreatedResources)
+
+	p.Return(res1)
+	p.Return(res2)
+
+	stat = p.Stat()
+	assert.Equal(t, 2, stat.IdleResources)
+	assert.Equal(t, 0, stat.AcquiredResources)
+
+	p.Close()
+
+	stat = p.Stat()
+	assert.EqualValues(t, 2, stat.ClosedResources)
+	assert.Equal(t, 2, closeCalls.Value())
+}
+
+func TestPoolStatCountsCanceledAcquire(t *testing.T) {
+	createFunc := func() (interface{}, error) {
+		return 1, nil
+	}
+	p := pool.New(createFunc, stubCloseRes)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := p.Get(ctx)
+	assert.Equal(t, context.Canceled, err)
+
+	assert.EqualValues(t, 1, p.Stat().CanceledAcquireCount)
+}
+
+func TestPoolTryGetReturnsFalseWhenNoIdleResource(t *testing.T) {
+	var createCalls Counter
+	createFunc := func() (interface{}, error) {
+		return createCalls.Next(), nil
+	}
+	p := pool.New(createFunc, stubCloseRes)
+
+	res, ok := p.TryGet()
+	assert.False(t, ok)
+	assert.Nil(t, res)
+	assert.Equal(t, 0, createCalls.Value())
+}
+
+func TestPoolTryGetReturnsIdleResource(t *testing.T) {
+	var createCalls Counter
+	createFunc := func() (interface{}, error) {
+		return createCalls.Next(), nil
+	}
+	p := pool.New(createFunc, stubCloseRes)
+
+	res, err := p.Get(context.Background())
+	require.NoError(t, err)
+	p.Return(res)
+
+	got, ok := p.TryGet()
+	assert.True(t, ok)
+	assert.Equal(t, res, got)
+}
+
+func TestPoolTryGetReturnsFalseAfterClose(t *testing.T) {
+	var createCalls Counter
+	createFunc := func() (interface{}, error) {
+		return createCalls.Next(), nil
+	}
+	p := pool.New(createFunc, stubCloseRes)
+
+	res, err := p.Get(context.Background())
+	require.NoError(t, err)
+	p.Return(res)
+	p.Close()
+
+	got, ok := p.TryGet()
+	assert.False(t, ok)
+	assert.Nil(t, got)
+}
+
+func TestPoolAcquireAllIdleDrainsIdleResources(t *testing.T) {
+	var createCalls Counter
+	createFunc := func() (interface{}, error) {
+		return createCalls.Next(), nil
+	}
+	p := pool.New(createFunc, stubCloseRes)
+
+	resources := make([]interface{}, 3)
+	for i := range resources {
+		var err error
+		resources[i], err = p.Get(context.Background())
+		require.NoError(t, err)
+	}
+	for _, res := range resources {
+		p.Return(res)
+	}
+
+	all := p.AcquireAllIdle()
+	assert.ElementsMatch(t, resources, all)
+
+	_, ok := p.TryGet()
+	assert.False(t, ok)
+
+	for _, res := range all {
+		p.Return(res)
+	}
+	assert.Equal(t, 3, p.Size())
+}
+
+func TestPoolAcquireAllIdleReturnsEmptyAfterClose(t *testing.T) {
+	var createCalls Counter
+	createFunc := func() (interface{}, error) {
+		return createCalls.Next(), nil
+	}
+	p := pool.New(createFunc, stubCloseRes)
+	p.Close()
+
+	assert.Empty(t, p.AcquireAllIdle())
+}
+
 func BenchmarkPoolGetAndReturnNoContention(b *testing.B) {
 	var createCalls Counter
 	createFunc := func() (interface{}, error) {
@@ -280,4 +872,4 @@ func BenchmarkPoolGetAndReturnHeavyContention(b *testing.B) {
 		}
 		pool.Return(res)
 	}
-}
\ No newline at end of file
+}